@@ -0,0 +1,384 @@
+// Package board implements the core Othello game model: board state,
+// moves, and the rules for executing them. It depends only on the
+// standard library, golang.org/x/net/context and the App Engine
+// logging client, never on the
+// othello root package or any of its HTTP handlers, so search, eval,
+// sgf, and store can all import it without creating a cycle back to
+// the package that in turn imports them.
+package board
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine/log"
+)
+
+// Ctx is the request context used by the logging helpers below
+// (PrintLog's explicit parameter aside). The othello package's getMove
+// handler sets it at the start of each request it serves; the other
+// handlers do not set it.
+var Ctx context.Context
+
+var ScoreMap [8][8]int
+
+// zobristKeys holds one random key per (piece kind, square) pair, used
+// to maintain Board.Hash incrementally. It is indexed by Piece (Empty
+// is unused but kept so the Piece value can index directly) and by
+// square index (y-1)*8+(x-1).
+var zobristKeys [3][64]uint64
+
+// zobristTurnKey is XORed into Board.Hash whenever Next toggles, so
+// the same layout with Black and White to move hashes differently.
+var zobristTurnKey uint64
+
+func init() {
+	// A fixed seed keeps hashes (and thus transposition-table
+	// behavior) reproducible between runs, which is handy when
+	// debugging search.
+	rng := rand.New(rand.NewSource(1))
+	for piece := range zobristKeys {
+		for sq := range zobristKeys[piece] {
+			zobristKeys[piece][sq] = rng.Uint64()
+		}
+	}
+	zobristTurnKey = rng.Uint64()
+
+	ScoreMap = [8][8]int{
+		{15, 2, 5, 5, 5, 5, 2, 15},
+		{2, 0, 1, 1, 1, 1, 0, 2},
+		{5, 1, 1, 1, 1, 1, 1, 5},
+		{5, 1, 1, 1, 1, 1, 1, 5},
+		{5, 1, 1, 1, 1, 1, 1, 5},
+		{5, 1, 1, 1, 1, 1, 1, 5},
+		{2, 0, 1, 1, 1, 1, 0, 2},
+		{15, 2, 5, 5, 5, 5, 2, 15},
+	}
+}
+
+type Piece int8
+
+const (
+	Empty Piece = iota
+	Black Piece = iota
+	White Piece = iota
+
+	// Red/Blue are aliases for Black/White
+	Red  = Black
+	Blue = White
+)
+
+func (p Piece) Opposite() Piece {
+	switch p {
+	case White:
+		return Black
+	case Black:
+		return White
+	default:
+		return Empty
+	}
+}
+
+type Board struct {
+	// Layout says what pieces are where.
+	Pieces [8][8]Piece
+	// Next says what the color of the next piece played must be.
+	Next  Piece
+	Moves MoveList
+
+	// BB mirrors Pieces as a Bitboard, and Hash is its Zobrist hash.
+	// Both are maintained incrementally by Exec/realMove/place, so
+	// after Sync has been called once they stay correct without ever
+	// being recomputed from scratch. Neither is part of the Board's
+	// JSON wire format; callers that build a Board by hand (e.g. via
+	// json.Unmarshal) must call Sync before relying on them.
+	BB   Bitboard
+	Hash uint64
+}
+
+// Bitboard is a compact, incrementally-maintained mirror of a Board's
+// piece layout: one bit per square (square index (y-1)*8+(x-1)) in
+// each color's mask, plus whose turn it is.
+type Bitboard struct {
+	Black uint64
+	White uint64
+	Next  Piece
+}
+
+// squareIndex maps a board Position to its bit/Zobrist-key index.
+func squareIndex(p Position) uint {
+	return uint((p[1]-1)*8 + (p[0] - 1))
+}
+
+func (bb *Bitboard) set(p Position, piece Piece) {
+	bit := uint64(1) << squareIndex(p)
+	bb.Black &^= bit
+	bb.White &^= bit
+	switch piece {
+	case Black:
+		bb.Black |= bit
+	case White:
+		bb.White |= bit
+	}
+}
+
+// Sync recomputes BB and Hash from Pieces and Next from scratch. Call
+// it once after constructing a Board from outside the package (e.g.
+// after json.Unmarshal); from then on Exec keeps both up to date
+// incrementally.
+func (b *Board) Sync() {
+	b.BB = Bitboard{Next: b.Next}
+	b.Hash = 0
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			piece := b.Pieces[y][x]
+			if piece == Empty {
+				continue
+			}
+			pos := Position{x + 1, y + 1}
+			b.BB.set(pos, piece)
+			b.Hash ^= zobristKeys[piece][squareIndex(pos)]
+		}
+	}
+	if b.Next == White {
+		b.Hash ^= zobristTurnKey
+	}
+}
+
+func (b Board) PrintLog(ctx context.Context) {
+	for y := 0; y < 8; y++ {
+		var s string
+
+		for x := 0; x < 8; x++ {
+			switch b.Pieces[y][x] {
+			case White:
+				s += "w "
+			case Black:
+				s += "b "
+			default:
+				s += "  "
+			}
+		}
+		log.Infof(ctx, "%v\n", s)
+	}
+}
+
+// At returns a pointer to the piece at a given position.
+func (b *Board) At(p Position) *Piece {
+	return &b.Pieces[p[1]-1][p[0]-1]
+}
+
+// Get returns the piece at a given position.
+func (b *Board) Get(p Position) Piece {
+	return *b.At(p)
+}
+
+var (
+	// ErrOccupied means the targeted square already has a piece on it.
+	ErrOccupied = errors.New("square is occupied")
+	// ErrNoCapture means the move would not flip any opponent pieces.
+	ErrNoCapture = errors.New("move captures no pieces")
+	// ErrMustPass means a pass was attempted while a legal move exists.
+	ErrMustPass = errors.New("a legal move is available; passing is not allowed")
+)
+
+// MoveError is returned by Exec (and the internal helpers it calls)
+// when a move is illegal. Reason is always one of the Err* sentinels
+// above, so callers can switch on it with errors.Is instead of
+// matching message strings.
+type MoveError struct {
+	Move   Move
+	Reason error
+}
+
+func (e *MoveError) Error() string {
+	return fmt.Sprintf("%v illegal move: %v", e.Move, e.Reason)
+}
+
+func (e *MoveError) Unwrap() error { return e.Reason }
+
+// Exec runs a move on a given Board, updating the given board, and
+// returning it. Returns error if the move is illegal.
+func (b *Board) Exec(m Move) (*Board, error) {
+	if !m.Where.Pass() {
+		if _, err := b.realMove(m); err != nil {
+			return b, err
+		}
+	} else {
+		// Attempting to pass.
+		valid := b.ValidMoves()
+		if len(valid) > 0 {
+			return nil, &MoveError{Move: m, Reason: ErrMustPass}
+		}
+	}
+	b.Next = b.Next.Opposite()
+	b.BB.Next = b.Next
+	b.Hash ^= zobristTurnKey
+	return b, nil
+}
+
+// realMove executes a move that isn't a PASS.
+func (b *Board) realMove(m Move) (*Board, error) {
+	captures, err := b.tryMove(m)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range append(captures, m.Where) {
+		b.place(p, m.As)
+	}
+	return b, nil
+}
+
+// place sets the piece at p, keeping Pieces, BB and Hash in sync. It is
+// used both for fresh placements (old is Empty) and for flips caused
+// by a capture (old is the opponent's piece).
+func (b *Board) place(p Position, piece Piece) {
+	old := *b.At(p)
+	if old != Empty {
+		b.Hash ^= zobristKeys[old][squareIndex(p)]
+	}
+	*b.At(p) = piece
+	b.Hash ^= zobristKeys[piece][squareIndex(p)]
+	b.BB.set(p, piece)
+}
+
+func (b Board) GetMovedBoard(m Move) Board {
+	b.Exec(m)
+	return b
+}
+
+type BoardList []Board
+
+func (bl *BoardList) LogAll(ctx context.Context) {
+	log.Infof(ctx, "boards:")
+	for _, v := range *bl {
+		v.PrintLog(ctx)
+		log.Infof(ctx, "----")
+	}
+}
+
+// Position represents a position on the othello board. Valid board
+// coordinates are 1-8 (not 0-7)!
+type Position [2]int
+
+// Valid returns true iff this is a valid board position.
+func (p Position) Valid() bool {
+	ok := func(i int) bool { return 1 <= i && i <= 8 }
+	return ok(p[0]) && ok(p[1])
+}
+
+// Pass returns true iff this move position represents a pass.
+func (p Position) Pass() bool {
+	return !p.Valid()
+}
+
+// Move describes a move on an Othello board.
+type Move struct {
+	// Where a piece is going to be placed. If Where is zeros, or
+	// another invalid coordinate, it indicates a pass.
+	Where Position
+	// As is the player taking the player taking the turn.
+	As Piece
+}
+
+func (m Move) Send(w io.Writer, ctx context.Context) {
+	fmt.Fprintf(w, "[%d,%d]", m.Where[0], m.Where[1])
+	m.Log("Move to: ")
+}
+
+func (m Move) Log(prefix string) {
+	log.Infof(Ctx, "%s[%d,%d]", prefix, m.Where[0], m.Where[1])
+}
+
+func (m *Move) ToStr() string {
+	return fmt.Sprintf("[%d,%d]", m.Where[0], m.Where[1])
+}
+
+type MoveList []Move
+
+func (ml MoveList) LogAll(ctx context.Context) {
+	log.Infof(ctx, "moves:")
+	for _, v := range ml {
+		v.Log("")
+	}
+}
+
+type direction Position
+
+var dirs []direction
+
+func init() {
+	for x := -1; x <= 1; x++ {
+		for y := -1; y <= 1; y++ {
+			if x == 0 && y == 0 {
+				continue
+			}
+			dirs = append(dirs, direction{x, y})
+		}
+	}
+}
+
+// tryMove tries a non-PASS move without actually executing it.
+// Returns the list of captures that would happen.
+func (b *Board) tryMove(m Move) ([]Position, error) {
+	if b.Get(m.Where) != Empty {
+		return nil, &MoveError{Move: m, Reason: ErrOccupied}
+	}
+
+	var captures []Position
+	for _, dir := range dirs {
+		captures = append(captures, b.findCaptures(m, dir)...)
+	}
+
+	if len(captures) < 1 {
+		return nil, &MoveError{Move: m, Reason: ErrNoCapture}
+	}
+	return captures, nil
+}
+
+func translate(p Position, d direction) Position {
+	return Position{p[0] + d[0], p[1] + d[1]}
+}
+
+func (b *Board) findCaptures(m Move, dir direction) []Position {
+	var caps []Position
+	for p := m.Where; true; caps = append(caps, p) {
+		p = translate(p, dir)
+		if !p.Valid() {
+			// End of board.
+			return []Position{}
+		}
+		switch *b.At(p) {
+		case m.As:
+			return caps
+		case Empty:
+			return []Position{}
+		}
+	}
+	panic("impossible")
+}
+
+func (b *Board) ValidMoves() MoveList {
+	return b.ValidMovesFor(b.Next)
+}
+
+// ValidMovesFor returns the legal moves for the given color,
+// regardless of whose turn Next actually says it is. Evaluators use
+// this to measure both sides' mobility from the same board.
+func (b *Board) ValidMovesFor(p Piece) MoveList {
+	var moves MoveList
+	for y := 1; y <= 8; y++ {
+		for x := 1; x <= 8; x++ {
+			m := Move{Where: Position{x, y}, As: p}
+			_, err := b.tryMove(m)
+			if err == nil {
+				moves = append(moves, m)
+			}
+		}
+	}
+	return moves
+}
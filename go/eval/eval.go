@@ -0,0 +1,238 @@
+// Package eval provides pluggable static evaluation functions for
+// Othello boards. The search package uses an Evaluator to score leaf
+// nodes, so different evaluation strategies can be swapped in and
+// benchmarked against each other.
+package eval
+
+import othello "github.com/hikalium/hw7/go/board"
+
+// Evaluator scores a board from toMove's perspective: positive favors
+// toMove, negative favors the opponent.
+type Evaluator interface {
+	Evaluate(b *othello.Board, toMove othello.Piece) int
+}
+
+// DefaultEvaluator is what the search subsystem uses when nothing
+// more specific is configured.
+var DefaultEvaluator Evaluator = Composite{}
+
+// PieceCount scores a board by simple disc differential. It is most
+// meaningful in the endgame, where maximizing final disc count is the
+// only thing that matters.
+type PieceCount struct{}
+
+func (PieceCount) Evaluate(b *othello.Board, toMove othello.Piece) int {
+	var black, white int
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			switch b.Pieces[y][x] {
+			case othello.Black:
+				black++
+			case othello.White:
+				white++
+			}
+		}
+	}
+	return fromBlack(black-white, toMove)
+}
+
+// PositionalWeights scores a board using the static per-square
+// weights in othello.ScoreMap: corners and certain edge squares are
+// worth more than interior squares. This is the evaluation the engine
+// used before pluggable Evaluators existed.
+type PositionalWeights struct{}
+
+func (PositionalWeights) Evaluate(b *othello.Board, toMove othello.Piece) int {
+	var score int
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			switch b.Pieces[y][x] {
+			case othello.Black:
+				score += othello.ScoreMap[y][x]
+			case othello.White:
+				score -= othello.ScoreMap[y][x]
+			}
+		}
+	}
+	return fromBlack(score, toMove)
+}
+
+// Composite combines corner occupancy, mobility, potential mobility,
+// frontier-disc penalty and edge stability, weighted by how far the
+// game has progressed. In the last ~12 plies, where the exact final
+// disc count is what wins the game, it switches to plain
+// PieceCount instead.
+type Composite struct{}
+
+func (Composite) Evaluate(b *othello.Board, toMove othello.Piece) int {
+	discs := discCount(b)
+	if 64-discs <= 12 {
+		return PieceCount{}.Evaluate(b, toMove)
+	}
+
+	w := weightsFor(discs)
+	score := w.corner*cornerScore(b) +
+		w.mobility*mobilityScore(b) +
+		w.potential*potentialMobilityScore(b) +
+		w.frontier*frontierScore(b) +
+		w.stability*stabilityScore(b)
+	return fromBlack(score, toMove)
+}
+
+// fromBlack converts a Black-positive score into toMove's
+// perspective, matching the sign convention every term below is
+// computed in.
+func fromBlack(score int, toMove othello.Piece) int {
+	if toMove == othello.White {
+		return -score
+	}
+	return score
+}
+
+func discCount(b *othello.Board) int {
+	var n int
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if b.Pieces[y][x] != othello.Empty {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// phaseWeights are the relative importance of each Composite term.
+// Disc count is used as a cheap proxy for game phase: few discs means
+// opening (mobility and frontier shape matter most), many means
+// midgame sliding toward the endgame (corners and stability start to
+// dominate).
+type phaseWeights struct {
+	corner, mobility, potential, frontier, stability int
+}
+
+func weightsFor(discs int) phaseWeights {
+	switch {
+	case discs <= 20:
+		return phaseWeights{corner: 25, mobility: 5, potential: 2, frontier: 3, stability: 3}
+	case discs <= 44:
+		return phaseWeights{corner: 35, mobility: 3, potential: 1, frontier: 2, stability: 6}
+	default:
+		return phaseWeights{corner: 40, mobility: 1, potential: 1, frontier: 1, stability: 10}
+	}
+}
+
+var corners = [4]othello.Position{{1, 1}, {1, 8}, {8, 1}, {8, 8}}
+
+func cornerScore(b *othello.Board) int {
+	var score int
+	for _, c := range corners {
+		switch b.Get(c) {
+		case othello.Black:
+			score++
+		case othello.White:
+			score--
+		}
+	}
+	return score
+}
+
+// mobilityScore is the difference in the number of legal moves each
+// side has right now.
+func mobilityScore(b *othello.Board) int {
+	return len(b.ValidMovesFor(othello.Black)) - len(b.ValidMovesFor(othello.White))
+}
+
+// potentialMobilityScore counts empty squares adjacent to the
+// opponent's discs for each side: squares that could turn into a
+// legal move later, even if they aren't one yet.
+func potentialMobilityScore(b *othello.Board) int {
+	return countEmptyAdjacentTo(b, othello.White) - countEmptyAdjacentTo(b, othello.Black)
+}
+
+func countEmptyAdjacentTo(b *othello.Board, piece othello.Piece) int {
+	var count int
+	forEachSquare(func(pos othello.Position) {
+		if b.Get(pos) == othello.Empty && hasNeighbor(b, pos, piece) {
+			count++
+		}
+	})
+	return count
+}
+
+// frontierScore penalizes discs that sit next to an empty square:
+// such "frontier" discs are exposed to being outflanked later.
+func frontierScore(b *othello.Board) int {
+	return countFrontier(b, othello.White) - countFrontier(b, othello.Black)
+}
+
+func countFrontier(b *othello.Board, piece othello.Piece) int {
+	var count int
+	forEachSquare(func(pos othello.Position) {
+		if b.Get(pos) == piece && hasNeighbor(b, pos, othello.Empty) {
+			count++
+		}
+	})
+	return count
+}
+
+// stabilityRun is one direction along an edge, starting from a
+// corner; stabilityScore walks it to find a corner-anchored run of
+// same-colored discs that, once the corner itself is taken, can never
+// be flipped.
+type stabilityRun struct {
+	start  othello.Position
+	dx, dy int
+}
+
+var stabilityRuns = []stabilityRun{
+	{othello.Position{1, 1}, 1, 0}, {othello.Position{1, 1}, 0, 1},
+	{othello.Position{8, 1}, -1, 0}, {othello.Position{8, 1}, 0, 1},
+	{othello.Position{1, 8}, 1, 0}, {othello.Position{1, 8}, 0, -1},
+	{othello.Position{8, 8}, -1, 0}, {othello.Position{8, 8}, 0, -1},
+}
+
+func stabilityScore(b *othello.Board) int {
+	counted := make(map[othello.Position]bool)
+	var black, white int
+	for _, run := range stabilityRuns {
+		color := b.Get(run.start)
+		if color == othello.Empty {
+			continue
+		}
+		for pos := run.start; pos.Valid() && b.Get(pos) == color; pos = (othello.Position{pos[0] + run.dx, pos[1] + run.dy}) {
+			if counted[pos] {
+				continue
+			}
+			counted[pos] = true
+			if color == othello.Black {
+				black++
+			} else {
+				white++
+			}
+		}
+	}
+	return black - white
+}
+
+func hasNeighbor(b *othello.Board, pos othello.Position, piece othello.Piece) bool {
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+			n := othello.Position{pos[0] + dx, pos[1] + dy}
+			if n.Valid() && b.Get(n) == piece {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func forEachSquare(f func(othello.Position)) {
+	for y := 1; y <= 8; y++ {
+		for x := 1; x <= 8; x++ {
+			f(othello.Position{x, y})
+		}
+	}
+}
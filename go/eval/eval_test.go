@@ -0,0 +1,51 @@
+package eval
+
+import (
+	"testing"
+
+	othello "github.com/hikalium/hw7/go/board"
+)
+
+func boardWithCorner(piece othello.Piece) othello.Board {
+	var b othello.Board
+	b.Pieces[0][0] = piece
+	b.Sync()
+	return b
+}
+
+func TestPieceCountSignConvention(t *testing.T) {
+	var b othello.Board
+	b.Pieces[0][0] = othello.Black
+	b.Pieces[0][1] = othello.Black
+	b.Pieces[0][2] = othello.White
+	b.Sync()
+
+	if s := (PieceCount{}).Evaluate(&b, othello.Black); s != 1 {
+		t.Fatalf("PieceCount for Black = %d, want 1 (2 black - 1 white)", s)
+	}
+	if s := (PieceCount{}).Evaluate(&b, othello.White); s != -1 {
+		t.Fatalf("PieceCount for White = %d, want -1", s)
+	}
+}
+
+func TestPositionalWeightsFavorsCornerOccupant(t *testing.T) {
+	b := boardWithCorner(othello.Black)
+	if s := (PositionalWeights{}).Evaluate(&b, othello.Black); s <= 0 {
+		t.Fatalf("corner occupancy scored %d for Black to move, want > 0", s)
+	}
+	if s := (PositionalWeights{}).Evaluate(&b, othello.White); s >= 0 {
+		t.Fatalf("corner occupancy scored %d for White to move, want < 0", s)
+	}
+}
+
+func TestCompositeFavorsCornerOccupant(t *testing.T) {
+	blackCorner := boardWithCorner(othello.Black)
+	if s := (Composite{}).Evaluate(&blackCorner, othello.Black); s <= 0 {
+		t.Fatalf("Black holding a corner scored %d for Black to move, want > 0", s)
+	}
+
+	whiteCorner := boardWithCorner(othello.White)
+	if s := (Composite{}).Evaluate(&whiteCorner, othello.Black); s >= 0 {
+		t.Fatalf("White holding a corner scored %d for Black to move, want < 0", s)
+	}
+}
@@ -0,0 +1,207 @@
+package othello
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+
+	"github.com/hikalium/hw7/go/search"
+	"github.com/hikalium/hw7/go/store"
+)
+
+func init() {
+	http.HandleFunc("/game", serveGameCreate)
+	http.HandleFunc("/game/", serveGameSub)
+}
+
+// gameStore is where REST game sessions live. It's a package var
+// (rather than threaded through every handler) so it can be swapped
+// for store.NewMemoryStore() in tests.
+var gameStore store.Store = store.NewDatastoreStore()
+
+// serveGameCreate handles POST /game: create a game and tell the
+// caller they're playing Black.
+func serveGameCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := appengine.NewContext(r)
+	g, err := gameStore.Create(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, struct {
+		ID      string `json:"id"`
+		YouPlay string `json:"youPlay"`
+	}{ID: g.ID, YouPlay: "black"})
+}
+
+// serveGameSub dispatches the /game/{id}[/move[/{n}]|/join] routes
+// that all hang off a game ID.
+func serveGameSub(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/game/"), "/")
+	if len(parts) == 0 || parts[0] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	id := parts[0]
+	ctx := appengine.NewContext(r)
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		serveGameGet(ctx, w, id)
+	case len(parts) == 2 && parts[1] == "move" && r.Method == http.MethodPost:
+		serveGameMove(ctx, w, r, id)
+	case len(parts) == 2 && parts[1] == "join" && r.Method == http.MethodPost:
+		serveGameJoin(ctx, w, id)
+	case len(parts) == 3 && parts[1] == "move" && r.Method == http.MethodGet:
+		serveGameMoveAt(ctx, w, r, id, parts[2])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveGameGet handles GET /game/{id}: the current board.
+func serveGameGet(ctx context.Context, w http.ResponseWriter, id string) {
+	g, err := gameStore.Get(ctx, id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, g.Board())
+}
+
+type moveRequest struct {
+	Where [2]int `json:"where"`
+}
+
+// serveGameMove handles POST /game/{id}/move: apply the caller's
+// move, then, unless a second player has joined, play and apply the
+// AI's reply too.
+func serveGameMove(ctx context.Context, w http.ResponseWriter, r *http.Request, id string) {
+	var req moveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid json: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	g, err := gameStore.Get(ctx, id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	board := g.Board()
+	m := Move{Where: Position{req.Where[0], req.Where[1]}, As: board.Next}
+	if _, err := board.Exec(m); err != nil {
+		http.Error(w, fmt.Sprintf("invalid move: %v", err), http.StatusBadRequest)
+		return
+	}
+	if g, err = gameStore.AppendMove(ctx, id, m); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Let the AI take its turn, auto-passing (and persisting the pass)
+	// for as long as it has no legal move, the same way ws.go's
+	// settleTurn does for WebSocket games. Without this, a pass-forced
+	// AI turn would never be recorded and board.Next would stay stuck
+	// on White forever.
+	var reply *Move
+	for !g.TwoPlayer && board.Next == White {
+		if len(board.ValidMoves()) == 0 {
+			if _, err := board.Exec(Move{}); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if g, err = gameStore.AppendMove(ctx, id, Move{}); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			continue
+		}
+		searchCtx, cancel := context.WithTimeout(ctx, maxSearchDuration)
+		best, _ := search.SearchUntil(searchCtx, &board, maxSearchDepth)
+		cancel()
+		if _, err := board.Exec(best); err != nil {
+			break
+		}
+		if g, err = gameStore.AppendMove(ctx, id, best); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		reply = &best
+		break
+	}
+
+	resp := struct {
+		Board    Board  `json:"board"`
+		Reply    *Move  `json:"reply,omitempty"`
+		GameOver bool   `json:"gameOver,omitempty"`
+		Winner   string `json:"winner,omitempty"`
+	}{Board: g.Board(), Reply: reply}
+	// Mirror ws.go's settleTurn+broadcastGameOver: neither color having
+	// a legal move, regardless of whose turn it nominally is, means the
+	// game has ended. Polling REST clients have no other way to learn
+	// this, unlike WebSocket clients which get a "gameOver" message.
+	if len(board.ValidMovesFor(Black)) == 0 && len(board.ValidMovesFor(White)) == 0 {
+		resp.GameOver = true
+		resp.Winner = winner(board)
+	}
+	writeJSON(w, resp)
+}
+
+// serveGameMoveAt handles GET /game/{id}/move/{n}: the nth move
+// played so far.
+func serveGameMoveAt(ctx context.Context, w http.ResponseWriter, r *http.Request, id, nStr string) {
+	n, err := strconv.Atoi(nStr)
+	if err != nil || n < 0 {
+		http.Error(w, "invalid move index", http.StatusBadRequest)
+		return
+	}
+	g, err := gameStore.Get(ctx, id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if n >= len(g.Moves) {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, g.Moves[n])
+}
+
+// serveGameJoin handles POST /game/{id}/join: claim White as the
+// second player, switching the game out of vs-AI mode.
+func serveGameJoin(ctx context.Context, w http.ResponseWriter, id string) {
+	if _, err := gameStore.Join(ctx, id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, struct {
+		YouPlay string `json:"youPlay"`
+	}{YouPlay: "white"})
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch err {
+	case store.ErrNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case store.ErrAlreadyJoined:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,201 @@
+// Package search implements a negamax game-tree search with alpha-beta
+// pruning and iterative deepening for the othello engine. It operates on
+// *othello.Board values and is otherwise independent of the HTTP layer.
+package search
+
+import (
+	"context"
+	"sort"
+
+	othello "github.com/hikalium/hw7/go/board"
+	"github.com/hikalium/hw7/go/eval"
+)
+
+// Config tunes what the search subsystem does beyond depth/deadline,
+// so it can be benchmarked with different settings. The zero Config
+// is the default: eval.DefaultEvaluator scores leaf nodes.
+type Config struct {
+	Evaluator eval.Evaluator
+}
+
+func (c Config) evaluator() eval.Evaluator {
+	if c.Evaluator != nil {
+		return c.Evaluator
+	}
+	return eval.DefaultEvaluator
+}
+
+// Infinity is used as the initial alpha/beta window. It is kept well
+// clear of any score the evaluators in package eval produce (at most a
+// few hundred in magnitude) so it never collides with an actual
+// evaluation.
+const Infinity = 1 << 30
+
+// maxPly bounds the principal-variation table. No request-driven search
+// will ever approach this many plies.
+const maxPly = 64
+
+// pvTable remembers, per ply, the best move of the most recent node
+// completed at that ply, so the next node searched at the same ply can
+// try it first and make alpha-beta pruning effective. It is shared by
+// every pass of iterative deepening and updated as each node completes:
+// at ply 0 it holds the root's best move from the last completed pass,
+// while at deeper plies it holds whichever node finished last, which is
+// not necessarily on the principal variation.
+type pvTable struct {
+	move [maxPly]othello.Move
+	ok   [maxPly]bool
+}
+
+func (t *pvTable) get(ply int) (othello.Move, bool) {
+	if ply < 0 || ply >= maxPly || !t.ok[ply] {
+		return othello.Move{}, false
+	}
+	return t.move[ply], true
+}
+
+func (t *pvTable) set(ply int, m othello.Move) {
+	if ply < 0 || ply >= maxPly {
+		return
+	}
+	t.move[ply] = m
+	t.ok[ply] = true
+}
+
+// Search runs a fixed-depth negamax search with alpha-beta pruning and
+// returns the best move for b.Next together with its score from
+// b.Next's perspective.
+func Search(ctx context.Context, b *othello.Board, depth int) (bestMove othello.Move, score int) {
+	return SearchWithConfig(ctx, b, depth, Config{})
+}
+
+// SearchUntil runs iterative deepening, starting at depth 1 and
+// increasing one ply at a time, until either maxDepth is reached or
+// ctx's deadline fires. It always returns the best move found by the
+// deepest iteration that ran to completion, which fits request
+// handlers bound by the App Engine request deadline. The transposition
+// table is shared across iterations, but a probe only accepts an entry
+// searched at least as deep as the node being searched, so scores
+// stored by a shallower pass do not settle nodes of a deeper one.
+func SearchUntil(ctx context.Context, b *othello.Board, maxDepth int) (bestMove othello.Move, score int) {
+	return SearchUntilWithConfig(ctx, b, maxDepth, Config{})
+}
+
+// SearchWithConfig is Search with an explicit Config, e.g. to
+// benchmark a non-default Evaluator.
+func SearchWithConfig(ctx context.Context, b *othello.Board, depth int, cfg Config) (bestMove othello.Move, score int) {
+	var table pvTable
+	tt := newTransTable()
+	move, s, _ := negamax(ctx, b, depth, 0, -Infinity, Infinity, &table, tt, cfg.evaluator())
+	return move, s
+}
+
+// SearchUntilWithConfig is SearchUntil with an explicit Config.
+func SearchUntilWithConfig(ctx context.Context, b *othello.Board, maxDepth int, cfg Config) (bestMove othello.Move, score int) {
+	var table pvTable
+	tt := newTransTable()
+	ev := cfg.evaluator()
+	for depth := 1; depth <= maxDepth; depth++ {
+		move, s, ok := negamax(ctx, b, depth, 0, -Infinity, Infinity, &table, tt, ev)
+		if !ok {
+			break
+		}
+		bestMove, score = move, s
+	}
+	return bestMove, score
+}
+
+// negamax searches b to the given depth, returning the best move, its
+// score from b.Next's perspective, and whether the search completed
+// before ctx's deadline. A false completion flag means the returned
+// move/score are partial and must not be trusted by the caller.
+func negamax(ctx context.Context, b *othello.Board, depth, ply, alpha, beta int, table *pvTable, tt *transTable, ev eval.Evaluator) (othello.Move, int, bool) {
+	if err := ctx.Err(); err != nil {
+		return othello.Move{}, 0, false
+	}
+	if depth == 0 {
+		return othello.Move{}, ev.Evaluate(b, b.Next), true
+	}
+
+	origAlpha, origBeta := alpha, beta
+	if best, score, ok := tt.probe(b.Hash, depth, alpha, beta); ok {
+		return best, score, true
+	}
+
+	moves := b.ValidMoves()
+	if len(moves) == 0 {
+		// If neither player has a move once this pass is accounted
+		// for, treat it the same as any other leaf. Go through
+		// Exec (via GetMovedBoard) rather than toggling Next by
+		// hand, so BB and Hash stay correct.
+		passed := b.GetMovedBoard(othello.Move{})
+		if len(passed.ValidMoves()) == 0 {
+			return othello.Move{}, ev.Evaluate(b, b.Next), true
+		}
+		_, s, ok := negamax(ctx, &passed, depth-1, ply+1, -beta, -alpha, table, tt, ev)
+		return othello.Move{As: b.Next}, -s, ok
+	}
+
+	orderMoves(moves, table, ply)
+
+	best := moves[0]
+	bestScore := -Infinity
+	for _, m := range moves {
+		child := b.GetMovedBoard(m)
+		_, s, ok := negamax(ctx, &child, depth-1, ply+1, -beta, -alpha, table, tt, ev)
+		if !ok {
+			return best, bestScore, false
+		}
+		s = -s
+		if s > bestScore {
+			bestScore = s
+			best = m
+		}
+		if s > alpha {
+			alpha = s
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	table.set(ply, best)
+
+	flag := boundExact
+	switch {
+	case bestScore <= origAlpha:
+		flag = boundUpper
+	case bestScore >= origBeta:
+		flag = boundLower
+	}
+	tt.store(b.Hash, depth, bestScore, flag, best)
+
+	return best, bestScore, true
+}
+
+// orderMoves sorts moves so that alpha-beta pruning is effective: the
+// move table remembers for this ply comes first (if any), followed by
+// the rest ordered by the static ScoreMap weight of the square being
+// played.
+func orderMoves(moves othello.MoveList, table *pvTable, ply int) {
+	sort.Slice(moves, func(i, j int) bool {
+		return squareWeight(moves[i]) > squareWeight(moves[j])
+	})
+	hint, ok := table.get(ply)
+	if !ok {
+		return
+	}
+	for i, m := range moves {
+		if m.Where == hint.Where {
+			copy(moves[1:i+1], moves[:i])
+			moves[0] = m
+			break
+		}
+	}
+}
+
+func squareWeight(m othello.Move) int {
+	if m.Where.Pass() {
+		return 0
+	}
+	return othello.ScoreMap[m.Where[1]-1][m.Where[0]-1]
+}
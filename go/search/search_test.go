@@ -0,0 +1,61 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	othello "github.com/hikalium/hw7/go/board"
+	"github.com/hikalium/hw7/go/eval"
+)
+
+func standardBoard() othello.Board {
+	var b othello.Board
+	b.Pieces[3][3] = othello.White
+	b.Pieces[3][4] = othello.Black
+	b.Pieces[4][3] = othello.Black
+	b.Pieces[4][4] = othello.White
+	b.Next = othello.Black
+	b.Sync()
+	return b
+}
+
+// TestSearchReturnsLegalMove is a basic sanity check that negamax
+// doesn't wander off and suggest a move that isn't actually legal.
+func TestSearchReturnsLegalMove(t *testing.T) {
+	b := standardBoard()
+	valid := b.ValidMoves()
+	if len(valid) == 0 {
+		t.Fatal("expected legal moves from the starting position")
+	}
+	best, _ := Search(context.Background(), &b, 2)
+	for _, m := range valid {
+		if m.Where == best.Where {
+			return
+		}
+	}
+	t.Fatalf("Search returned %v, which is not among the legal moves %v", best, valid)
+}
+
+// TestSearchNoMovesReturnsPass covers negamax's terminal case: a board
+// with no empty squares has no legal move for either side, so the
+// search must bottom out as a pass scored by the evaluator rather than
+// recursing forever.
+func TestSearchNoMovesReturnsPass(t *testing.T) {
+	var b othello.Board
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			b.Pieces[y][x] = othello.Black
+		}
+	}
+	b.Next = othello.Black
+	b.Sync()
+
+	best, score := Search(context.Background(), &b, 3)
+	if !best.Where.Pass() {
+		t.Fatalf("expected a pass move on a full board, got %v", best)
+	}
+	want := eval.DefaultEvaluator.Evaluate(&b, b.Next)
+	if score != want {
+		t.Fatalf("score = %d, want %d (DefaultEvaluator on a full board)", score, want)
+	}
+}
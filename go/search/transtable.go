@@ -0,0 +1,78 @@
+package search
+
+import othello "github.com/hikalium/hw7/go/board"
+
+// boundFlag records whether a stored score is exact, or only a bound
+// because alpha-beta pruning cut the node short.
+type boundFlag int8
+
+const (
+	boundExact boundFlag = iota
+	boundLower
+	boundUpper
+)
+
+// ttEntry is one transposition-table slot.
+type ttEntry struct {
+	hash  uint64
+	depth int8
+	score int16
+	flag  boundFlag
+	best  othello.Move
+	used  bool
+}
+
+// ttSize is the number of slots in a transTable. Each slot holds a
+// single entry (no chaining), so a bigger table means fewer collisions
+// at the cost of more memory, which every search allocates afresh.
+const ttSize = 1 << 16
+
+// transTable is a fixed-size transposition table keyed by Board.Hash
+// mod the table size; see store for when an entry is replaced.
+type transTable struct {
+	entries []ttEntry
+}
+
+func newTransTable() *transTable {
+	return &transTable{entries: make([]ttEntry, ttSize)}
+}
+
+func (t *transTable) slot(hash uint64) *ttEntry {
+	return &t.entries[hash%uint64(len(t.entries))]
+}
+
+// probe returns a usable (move, score) pair if the table holds an
+// entry for hash that was searched at least as deep as depth and
+// whose bound lets it settle the current alpha-beta window.
+func (t *transTable) probe(hash uint64, depth, alpha, beta int) (othello.Move, int, bool) {
+	e := t.slot(hash)
+	if !e.used || e.hash != hash || int(e.depth) < depth {
+		return othello.Move{}, 0, false
+	}
+	score := int(e.score)
+	switch e.flag {
+	case boundExact:
+		return e.best, score, true
+	case boundLower:
+		if score >= beta {
+			return e.best, score, true
+		}
+	case boundUpper:
+		if score <= alpha {
+			return e.best, score, true
+		}
+	}
+	return othello.Move{}, 0, false
+}
+
+// store records the result of searching hash to depth, replacing
+// whatever was there unless the existing entry is for the same hash
+// and was searched deeper. An entry for a different hash that shares
+// the slot is always replaced, however deep it was searched.
+func (t *transTable) store(hash uint64, depth, score int, flag boundFlag, best othello.Move) {
+	e := t.slot(hash)
+	if e.used && e.hash == hash && int(e.depth) > depth {
+		return
+	}
+	*e = ttEntry{hash: hash, depth: int8(depth), score: int16(score), flag: flag, best: best, used: true}
+}
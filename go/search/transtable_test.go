@@ -0,0 +1,35 @@
+package search
+
+import (
+	"testing"
+
+	othello "github.com/hikalium/hw7/go/board"
+)
+
+func TestTransTableProbeStoreRoundTrip(t *testing.T) {
+	tt := newTransTable()
+	const hash = uint64(12345)
+
+	if _, _, ok := tt.probe(hash, 3, -Infinity, Infinity); ok {
+		t.Fatal("expected a miss on an empty table")
+	}
+
+	best := othello.Move{Where: othello.Position{3, 4}, As: othello.Black}
+	tt.store(hash, 3, 10, boundExact, best)
+
+	m, score, ok := tt.probe(hash, 3, -Infinity, Infinity)
+	if !ok || m.Where != best.Where || score != 10 {
+		t.Fatalf("probe after store = (%v, %d, %v), want (%v, 10, true)", m, score, ok, best)
+	}
+
+	if _, _, ok := tt.probe(hash, 4, -Infinity, Infinity); ok {
+		t.Fatal("expected a miss when probing deeper than the stored entry")
+	}
+
+	// A shallower store must not clobber a deeper entry already there.
+	tt.store(hash, 1, 99, boundExact, othello.Move{})
+	m2, score2, ok := tt.probe(hash, 3, -Infinity, Infinity)
+	if !ok || score2 != 10 || m2.Where != best.Where {
+		t.Fatalf("shallower store overwrote deeper entry: got (%v, %d, %v)", m2, score2, ok)
+	}
+}
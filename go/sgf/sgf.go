@@ -0,0 +1,229 @@
+// Package sgf reads and writes Smart Game Format files for Othello
+// (SGF GM[2]), as a sequence of othello.Moves that can be replayed
+// through Board.Exec. It implements only the subset of SGF needed for
+// a single linear game: no variations, and no setup (AB/AW)
+// properties beyond the standard Othello starting position.
+package sgf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	othello "github.com/hikalium/hw7/go/board"
+)
+
+// Metadata holds the game-info properties that live on an SGF root
+// node. GM[2], FF[4] and SZ[8] are implied by this package and aren't
+// stored here.
+type Metadata struct {
+	PlayerBlack string
+	PlayerWhite string
+	Date        string
+	Result      string
+}
+
+// property is one SGF "KIND[value]" pair, e.g. {"B", "cd"}.
+type property struct {
+	kind  string
+	value string
+}
+
+// Encode writes moves as an SGF game record with the given metadata.
+func Encode(moves []othello.Move, meta Metadata) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString("(;GM[2]FF[4]SZ[8]")
+	writeProp(&b, "PB", meta.PlayerBlack)
+	writeProp(&b, "PW", meta.PlayerWhite)
+	writeProp(&b, "DT", meta.Date)
+	writeProp(&b, "RE", meta.Result)
+
+	for _, m := range moves {
+		tag, err := moveTag(m.As)
+		if err != nil {
+			return nil, err
+		}
+		coord, err := encodeCoord(m.Where)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, ";%s[%s]", tag, coord)
+	}
+
+	b.WriteString(")")
+	return []byte(b.String()), nil
+}
+
+func writeProp(b *strings.Builder, kind, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(b, "%s[%s]", kind, escape(value))
+}
+
+func moveTag(as othello.Piece) (string, error) {
+	switch as {
+	case othello.Black:
+		return "B", nil
+	case othello.White:
+		return "W", nil
+	default:
+		return "", fmt.Errorf("sgf: move has no player to move (%v)", as)
+	}
+}
+
+func encodeCoord(p othello.Position) (string, error) {
+	if p.Pass() {
+		return "", nil
+	}
+	if !p.Valid() {
+		return "", fmt.Errorf("sgf: position %v is out of range", p)
+	}
+	return string([]byte{byte('a' + p[0] - 1), byte('a' + p[1] - 1)}), nil
+}
+
+func escape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `]`, `\]`)
+	return s
+}
+
+// Decode reads an SGF game record, returning the standard Othello
+// starting board, the sequence of moves played from it, and the
+// game-info metadata.
+func Decode(r io.Reader) (initialBoard othello.Board, moves []othello.Move, meta Metadata, err error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return othello.Board{}, nil, Metadata{}, err
+	}
+
+	nodes, err := parseNodes(string(data))
+	if err != nil {
+		return othello.Board{}, nil, Metadata{}, err
+	}
+
+	initialBoard = newStandardBoard()
+	for _, node := range nodes {
+		for _, p := range node {
+			switch p.kind {
+			case "PB":
+				meta.PlayerBlack = p.value
+			case "PW":
+				meta.PlayerWhite = p.value
+			case "DT":
+				meta.Date = p.value
+			case "RE":
+				meta.Result = p.value
+			case "GM", "FF":
+				// Game-type/file-format markers; nothing else to do.
+			case "SZ":
+				if p.value != "8" {
+					return othello.Board{}, nil, Metadata{}, fmt.Errorf("sgf: unsupported board size %q", p.value)
+				}
+			case "B", "W":
+				pos, err := decodeCoord(p.value)
+				if err != nil {
+					return othello.Board{}, nil, Metadata{}, err
+				}
+				as := othello.Black
+				if p.kind == "W" {
+					as = othello.White
+				}
+				moves = append(moves, othello.Move{Where: pos, As: as})
+			}
+		}
+	}
+	return initialBoard, moves, meta, nil
+}
+
+func decodeCoord(value string) (othello.Position, error) {
+	if value == "" {
+		// SGF represents a pass as an empty value, e.g. B[].
+		return othello.Position{}, nil
+	}
+	if len(value) != 2 {
+		return othello.Position{}, fmt.Errorf("sgf: malformed coordinate %q", value)
+	}
+	pos := othello.Position{int(value[0]-'a') + 1, int(value[1]-'a') + 1}
+	if !pos.Valid() {
+		return othello.Position{}, fmt.Errorf("sgf: coordinate %q is out of range", value)
+	}
+	return pos, nil
+}
+
+func newStandardBoard() othello.Board {
+	var b othello.Board
+	b.Pieces[3][3] = othello.White
+	b.Pieces[3][4] = othello.Black
+	b.Pieces[4][3] = othello.Black
+	b.Pieces[4][4] = othello.White
+	b.Next = othello.Black
+	b.Sync()
+	return b
+}
+
+// parseNodes splits an SGF game tree into its ";"-delimited nodes,
+// each a list of properties. It supports exactly one linear sequence
+// of nodes (no "(" ")" variations beyond the outermost pair).
+func parseNodes(s string) ([][]property, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "(")
+	s = strings.TrimSuffix(s, ")")
+
+	var nodes [][]property
+	var current []property
+	started := false
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if s == "" {
+			break
+		}
+		if s[0] == ';' {
+			if started {
+				nodes = append(nodes, current)
+			}
+			current = nil
+			started = true
+			s = s[1:]
+			continue
+		}
+		open := strings.IndexByte(s, '[')
+		if open < 0 {
+			return nil, fmt.Errorf("sgf: malformed node near %q", s)
+		}
+		kind := strings.TrimSpace(s[:open])
+		value, rest, err := readBracket(s[open+1:])
+		if err != nil {
+			return nil, err
+		}
+		current = append(current, property{kind: kind, value: value})
+		s = rest
+	}
+	if started {
+		nodes = append(nodes, current)
+	}
+	return nodes, nil
+}
+
+// readBracket reads a property value up to the next unescaped ']',
+// honoring "\]" and "\\" escapes, and returns what follows it.
+func readBracket(s string) (value, rest string, err error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+			if i >= len(s) {
+				return "", "", errors.New("sgf: dangling escape at end of value")
+			}
+			b.WriteByte(s[i])
+		case ']':
+			return b.String(), s[i+1:], nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", errors.New("sgf: unterminated property value")
+}
@@ -0,0 +1,53 @@
+package sgf
+
+import (
+	"bytes"
+	"testing"
+
+	othello "github.com/hikalium/hw7/go/board"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	moves := []othello.Move{
+		{Where: othello.Position{4, 3}, As: othello.Black},
+		{Where: othello.Position{3, 3}, As: othello.White},
+		{Where: othello.Position{}, As: othello.Black}, // pass
+	}
+	meta := Metadata{PlayerBlack: "Alice", PlayerWhite: "Bob", Date: "2026-07-26", Result: "B+4"}
+
+	data, err := Encode(moves, meta)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	_, gotMoves, gotMeta, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	if len(gotMoves) != len(moves) {
+		t.Fatalf("Decode returned %d moves, want %d", len(gotMoves), len(moves))
+	}
+	for i, want := range moves {
+		if gotMoves[i].Where != want.Where || gotMoves[i].As != want.As {
+			t.Errorf("move %d = %v, want %v", i, gotMoves[i], want)
+		}
+	}
+	if gotMeta != meta {
+		t.Errorf("metadata = %+v, want %+v", gotMeta, meta)
+	}
+}
+
+func TestDecodeEscapedValue(t *testing.T) {
+	const in = `(;GM[2]FF[4]SZ[8]PB[A\]B]RE[B\\W];B[cd])`
+	_, moves, meta, err := Decode(bytes.NewReader([]byte(in)))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if meta.PlayerBlack != "A]B" || meta.Result != `B\W` {
+		t.Fatalf("metadata = %+v, want PlayerBlack %q, Result %q", meta, "A]B", `B\W`)
+	}
+	if len(moves) != 1 || moves[0].As != othello.Black {
+		t.Fatalf("moves = %v, want a single Black move", moves)
+	}
+}
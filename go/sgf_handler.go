@@ -0,0 +1,62 @@
+package othello
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/log"
+
+	"github.com/hikalium/hw7/go/search"
+	"github.com/hikalium/hw7/go/sgf"
+)
+
+func init() {
+	http.HandleFunc("/sgf", serveSGF)
+}
+
+// sgfResponse is what /sgf returns after replaying an uploaded game:
+// the resulting Board, and the engine's suggested next move (unless
+// the side to move must pass).
+type sgfResponse struct {
+	Board         Board `json:"board"`
+	Suggested     *Move `json:"suggested,omitempty"`
+	SuggestedPass bool  `json:"suggestedPass,omitempty"`
+}
+
+// serveSGF replays an uploaded SGF file and reports the resulting
+// board plus what the engine would play next, for analyzing a
+// historical game.
+func serveSGF(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+	defer r.Body.Close()
+
+	board, moves, meta, err := sgf.Decode(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid sgf: %v", err), http.StatusBadRequest)
+		return
+	}
+	log.Infof(ctx, "sgf: %s (B) vs %s (W), %d moves", meta.PlayerBlack, meta.PlayerWhite, len(moves))
+
+	for _, m := range moves {
+		if _, err := board.Exec(m); err != nil {
+			http.Error(w, fmt.Sprintf("invalid move %v in sgf: %v", m, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp := sgfResponse{Board: board}
+	if len(board.ValidMoves()) == 0 {
+		resp.SuggestedPass = true
+	} else {
+		searchCtx, cancel := context.WithTimeout(ctx, maxSearchDuration)
+		defer cancel()
+		best, _ := search.SearchUntil(searchCtx, &board, maxSearchDepth)
+		resp.Suggested = &best
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
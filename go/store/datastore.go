@@ -0,0 +1,122 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine/datastore"
+
+	othello "github.com/hikalium/hw7/go/board"
+)
+
+// gameKind is the Datastore kind used for persisted games.
+const gameKind = "OthelloGame"
+
+// gameEntity is the Datastore representation of a Game. othello.Move
+// isn't itself a property-list-friendly type, so moves are flattened
+// into parallel slices.
+type gameEntity struct {
+	TwoPlayer bool
+	MoveX     []int64
+	MoveY     []int64
+	MoveAs    []int64
+}
+
+func (e gameEntity) toGame(id string) Game {
+	g := Game{ID: id, TwoPlayer: e.TwoPlayer}
+	for i := range e.MoveX {
+		g.Moves = append(g.Moves, othello.Move{
+			Where: othello.Position{int(e.MoveX[i]), int(e.MoveY[i])},
+			As:    othello.Piece(e.MoveAs[i]),
+		})
+	}
+	return g
+}
+
+func (e *gameEntity) appendMove(m othello.Move) {
+	e.MoveX = append(e.MoveX, int64(m.Where[0]))
+	e.MoveY = append(e.MoveY, int64(m.Where[1]))
+	e.MoveAs = append(e.MoveAs, int64(m.As))
+}
+
+type datastoreStore struct{}
+
+// NewDatastoreStore returns a Store backed by App Engine Datastore, so
+// games survive across instances.
+func NewDatastoreStore() Store {
+	return datastoreStore{}
+}
+
+func gameKey(ctx context.Context, id string) (*datastore.Key, error) {
+	intID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("store: invalid game id %q", id)
+	}
+	return datastore.NewKey(ctx, gameKind, "", intID, nil), nil
+}
+
+func (datastoreStore) Create(ctx context.Context) (Game, error) {
+	key := datastore.NewIncompleteKey(ctx, gameKind, nil)
+	key, err := datastore.Put(ctx, key, &gameEntity{})
+	if err != nil {
+		return Game{}, err
+	}
+	return Game{ID: strconv.FormatInt(key.IntID(), 10)}, nil
+}
+
+func (datastoreStore) Get(ctx context.Context, id string) (Game, error) {
+	key, err := gameKey(ctx, id)
+	if err != nil {
+		return Game{}, err
+	}
+	var entity gameEntity
+	if err := datastore.Get(ctx, key, &entity); err != nil {
+		if err == datastore.ErrNoSuchEntity {
+			return Game{}, ErrNotFound
+		}
+		return Game{}, err
+	}
+	return entity.toGame(id), nil
+}
+
+func (datastoreStore) AppendMove(ctx context.Context, id string, m othello.Move) (Game, error) {
+	key, err := gameKey(ctx, id)
+	if err != nil {
+		return Game{}, err
+	}
+	var entity gameEntity
+	if err := datastore.Get(ctx, key, &entity); err != nil {
+		if err == datastore.ErrNoSuchEntity {
+			return Game{}, ErrNotFound
+		}
+		return Game{}, err
+	}
+	entity.appendMove(m)
+	if _, err := datastore.Put(ctx, key, &entity); err != nil {
+		return Game{}, err
+	}
+	return entity.toGame(id), nil
+}
+
+func (datastoreStore) Join(ctx context.Context, id string) (Game, error) {
+	key, err := gameKey(ctx, id)
+	if err != nil {
+		return Game{}, err
+	}
+	var entity gameEntity
+	if err := datastore.Get(ctx, key, &entity); err != nil {
+		if err == datastore.ErrNoSuchEntity {
+			return Game{}, ErrNotFound
+		}
+		return Game{}, err
+	}
+	if entity.TwoPlayer {
+		return Game{}, ErrAlreadyJoined
+	}
+	entity.TwoPlayer = true
+	if _, err := datastore.Put(ctx, key, &entity); err != nil {
+		return Game{}, err
+	}
+	return entity.toGame(id), nil
+}
@@ -0,0 +1,119 @@
+// Package store persists Othello game sessions behind an ID, so a
+// game survives across App Engine instances (or, for tests, just
+// stays in memory).
+package store
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"golang.org/x/net/context"
+
+	othello "github.com/hikalium/hw7/go/board"
+)
+
+// ErrNotFound is returned when the requested game does not exist.
+var ErrNotFound = errors.New("store: game not found")
+
+// ErrAlreadyJoined is returned by Join when a second player has
+// already claimed White.
+var ErrAlreadyJoined = errors.New("store: game already has two players")
+
+// Game is the persisted state of one Othello session: the moves
+// played so far from the standard starting position, and whether a
+// second player has joined as White (if not, White is the AI).
+type Game struct {
+	ID        string
+	Moves     []othello.Move
+	TwoPlayer bool
+}
+
+// Board replays Moves from the standard starting position and
+// returns the resulting board.
+func (g Game) Board() othello.Board {
+	b := StandardBoard()
+	for _, m := range g.Moves {
+		b.Exec(m)
+	}
+	return b
+}
+
+// StandardBoard returns a fresh Othello starting position.
+func StandardBoard() othello.Board {
+	var b othello.Board
+	b.Pieces[3][3] = othello.White
+	b.Pieces[3][4] = othello.Black
+	b.Pieces[4][3] = othello.Black
+	b.Pieces[4][4] = othello.White
+	b.Next = othello.Black
+	b.Sync()
+	return b
+}
+
+// Store persists Games behind an ID. NewDatastoreStore is the
+// production implementation; NewMemoryStore is an in-memory one for
+// tests.
+type Store interface {
+	Create(ctx context.Context) (Game, error)
+	Get(ctx context.Context, id string) (Game, error)
+	AppendMove(ctx context.Context, id string, m othello.Move) (Game, error)
+	Join(ctx context.Context, id string) (Game, error)
+}
+
+type memoryStore struct {
+	mu     sync.Mutex
+	games  map[string]*Game
+	nextID int
+}
+
+// NewMemoryStore returns a Store that keeps games in memory. It does
+// not survive a process restart, which is exactly what makes it
+// convenient to swap in for Datastore in tests.
+func NewMemoryStore() Store {
+	return &memoryStore{games: make(map[string]*Game)}
+}
+
+func (s *memoryStore) Create(ctx context.Context) (Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	g := &Game{ID: strconv.Itoa(s.nextID)}
+	s.games[g.ID] = g
+	return *g, nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, id string) (Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.games[id]
+	if !ok {
+		return Game{}, ErrNotFound
+	}
+	return *g, nil
+}
+
+func (s *memoryStore) AppendMove(ctx context.Context, id string, m othello.Move) (Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.games[id]
+	if !ok {
+		return Game{}, ErrNotFound
+	}
+	g.Moves = append(g.Moves, m)
+	return *g, nil
+}
+
+func (s *memoryStore) Join(ctx context.Context, id string) (Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.games[id]
+	if !ok {
+		return Game{}, ErrNotFound
+	}
+	if g.TwoPlayer {
+		return Game{}, ErrAlreadyJoined
+	}
+	g.TwoPlayer = true
+	return *g, nil
+}
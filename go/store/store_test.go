@@ -0,0 +1,50 @@
+package store
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	othello "github.com/hikalium/hw7/go/board"
+)
+
+func TestMemoryStoreCRUD(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	g, err := s.Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if g.ID == "" {
+		t.Fatal("Create returned an empty ID")
+	}
+
+	if _, err := s.Get(ctx, "does-not-exist"); err != ErrNotFound {
+		t.Fatalf("Get(unknown) = %v, want ErrNotFound", err)
+	}
+
+	move := othello.Move{Where: othello.Position{4, 3}, As: othello.Black}
+	g, err = s.AppendMove(ctx, g.ID, move)
+	if err != nil {
+		t.Fatalf("AppendMove: %v", err)
+	}
+	if len(g.Moves) != 1 || g.Moves[0] != move {
+		t.Fatalf("Moves = %v, want [%v]", g.Moves, move)
+	}
+
+	if got := g.Board().Get(move.Where); got != othello.Black {
+		t.Fatalf("replayed board has %v at %v, want Black", got, move.Where)
+	}
+
+	g, err = s.Join(ctx, g.ID)
+	if err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	if !g.TwoPlayer {
+		t.Fatal("Join did not set TwoPlayer")
+	}
+	if _, err := s.Join(ctx, g.ID); err != ErrAlreadyJoined {
+		t.Fatalf("second Join = %v, want ErrAlreadyJoined", err)
+	}
+}
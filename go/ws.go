@@ -0,0 +1,296 @@
+package othello
+
+import (
+	"errors"
+	"sync"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/websocket"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/log"
+
+	"github.com/hikalium/hw7/go/search"
+
+	"net/http"
+)
+
+func init() {
+	http.Handle("/ws", websocket.Handler(serveWS))
+}
+
+// wsMessage is the wire format for /ws. Not every field is meaningful
+// for every Type: a "move" only carries Where, an "invalidMove" only
+// Reason, a "gameOver" only Winner, and a "state" carries Board/Next.
+type wsMessage struct {
+	Type   string       `json:"type"`
+	Where  [2]int       `json:"where,omitempty"`
+	Reason string       `json:"reason,omitempty"`
+	Winner string       `json:"winner,omitempty"`
+	Board  [8][8]string `json:"board,omitempty"`
+	Next   string       `json:"next,omitempty"`
+}
+
+// wsSession is one player's live connection inside a wsGame.
+type wsSession struct {
+	conn  *websocket.Conn
+	color Piece
+	game  *wsGame
+}
+
+// wsGame pairs up to two sessions (or one session and the AI) around a
+// shared Board. All access to board goes through mu.
+type wsGame struct {
+	mu      sync.Mutex
+	board   Board
+	players [2]*wsSession // index 0 plays Black, index 1 plays White
+	useAI   bool
+	aiColor Piece
+}
+
+func newWSGame() *wsGame {
+	var b Board
+	b.Pieces[3][3] = White
+	b.Pieces[3][4] = Black
+	b.Pieces[4][3] = Black
+	b.Pieces[4][4] = White
+	b.Next = Black
+	b.Sync()
+	return &wsGame{board: b}
+}
+
+// lobby pairs up sessions that didn't ask to play the AI directly.
+var (
+	lobbyMu sync.Mutex
+	waiting *wsSession
+)
+
+// serveWS upgrades to a WebSocket and runs one player's side of a
+// game: pair into a session, push state, then loop reading moves
+// until the connection closes.
+func serveWS(conn *websocket.Conn) {
+	ctx := appengine.NewContext(conn.Request())
+	vsAI := conn.Request().FormValue("ai") != ""
+
+	sess := &wsSession{conn: conn}
+	game := pairSession(sess, vsAI)
+
+	log.Infof(ctx, "ws: session joined as %v (vsAI=%v)", sess.color, vsAI)
+	broadcastState(ctx, game)
+
+	for {
+		var msg wsMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			break
+		}
+		if msg.Type != "move" {
+			continue
+		}
+		handleWSMove(ctx, game, sess, msg.Where)
+	}
+
+	leaveGame(sess)
+}
+
+// pairSession assigns sess a color and a wsGame: against the AI it
+// always gets its own game, otherwise it is paired with whichever
+// session is waiting in the lobby (or becomes the one waiting).
+func pairSession(sess *wsSession, vsAI bool) *wsGame {
+	lobbyMu.Lock()
+	defer lobbyMu.Unlock()
+
+	if vsAI {
+		game := newWSGame()
+		game.useAI = true
+		game.aiColor = White
+		game.players[0] = sess
+		sess.color = Black
+		sess.game = game
+		return game
+	}
+
+	if waiting != nil && waiting != sess {
+		game := waiting.game
+		game.players[1] = sess
+		sess.color = White
+		sess.game = game
+		waiting = nil
+		return game
+	}
+
+	game := newWSGame()
+	game.players[0] = sess
+	sess.color = Black
+	sess.game = game
+	waiting = sess
+	return game
+}
+
+func leaveGame(sess *wsSession) {
+	lobbyMu.Lock()
+	defer lobbyMu.Unlock()
+	if waiting == sess {
+		waiting = nil
+	}
+}
+
+// handleWSMove applies a move requested by sess, then lets the game
+// play itself forward through any forced passes and, if applicable,
+// the AI's reply.
+func handleWSMove(ctx context.Context, game *wsGame, sess *wsSession, where [2]int) {
+	game.mu.Lock()
+	if game.board.Next != sess.color {
+		game.mu.Unlock()
+		sendInvalid(ctx, sess, "not your turn")
+		return
+	}
+	m := Move{Where: Position{where[0], where[1]}, As: sess.color}
+	_, err := game.board.Exec(m)
+	if err != nil {
+		game.mu.Unlock()
+		sendInvalid(ctx, sess, reasonFor(err))
+		return
+	}
+	over := settleTurn(&game.board)
+	game.mu.Unlock()
+
+	broadcastState(ctx, game)
+	if over {
+		broadcastGameOver(ctx, game)
+		return
+	}
+	driveAI(ctx, game)
+}
+
+// driveAI plays the AI's move (if this is its game and its turn), then
+// settles forced passes and broadcasts the result. It loops for as
+// long as the AI is left to move again, which happens when
+// settleTurn's pass was the human's: with no one else to act, the AI
+// must move again rather than leave the game waiting on a human who
+// has nothing to send.
+func driveAI(ctx context.Context, game *wsGame) {
+	for {
+		game.mu.Lock()
+		if !game.useAI || game.board.Next != game.aiColor {
+			game.mu.Unlock()
+			return
+		}
+		searchCtx, cancel := context.WithTimeout(ctx, maxSearchDuration)
+		best, _ := search.SearchUntil(searchCtx, &game.board, maxSearchDepth)
+		cancel()
+		game.board.Exec(best)
+		over := settleTurn(&game.board)
+		game.mu.Unlock()
+
+		broadcastState(ctx, game)
+		if over {
+			broadcastGameOver(ctx, game)
+			return
+		}
+	}
+}
+
+// settleTurn passes automatically for a side with no legal move, and
+// reports whether neither side has one (i.e. the game has ended).
+// Callers must hold game.mu.
+func settleTurn(board *Board) bool {
+	if len(board.ValidMoves()) > 0 {
+		return false
+	}
+	board.Exec(Move{})
+	return len(board.ValidMoves()) == 0
+}
+
+func sendInvalid(ctx context.Context, sess *wsSession, reason string) {
+	if err := websocket.JSON.Send(sess.conn, wsMessage{Type: "invalidMove", Reason: reason}); err != nil {
+		log.Infof(ctx, "ws: send invalidMove failed: %v", err)
+	}
+}
+
+func broadcastState(ctx context.Context, game *wsGame) {
+	game.mu.Lock()
+	board := game.board
+	game.mu.Unlock()
+
+	msg := wsMessage{Type: "state", Next: pieceLabel(board.Next)}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			msg.Board[y][x] = pieceLabel(board.Pieces[y][x])
+		}
+	}
+	for _, s := range game.players {
+		if s == nil {
+			continue
+		}
+		if err := websocket.JSON.Send(s.conn, msg); err != nil {
+			log.Infof(ctx, "ws: send state failed: %v", err)
+		}
+	}
+}
+
+func broadcastGameOver(ctx context.Context, game *wsGame) {
+	game.mu.Lock()
+	board := game.board
+	game.mu.Unlock()
+
+	msg := wsMessage{Type: "gameOver", Winner: winner(board)}
+	for _, s := range game.players {
+		if s == nil {
+			continue
+		}
+		if err := websocket.JSON.Send(s.conn, msg); err != nil {
+			log.Infof(ctx, "ws: send gameOver failed: %v", err)
+		}
+	}
+}
+
+func pieceLabel(p Piece) string {
+	switch p {
+	case Black:
+		return "black"
+	case White:
+		return "white"
+	default:
+		return ""
+	}
+}
+
+func winner(board Board) string {
+	var black, white int
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			switch board.Pieces[y][x] {
+			case Black:
+				black++
+			case White:
+				white++
+			}
+		}
+	}
+	switch {
+	case black > white:
+		return "black"
+	case white > black:
+		return "white"
+	default:
+		return "draw"
+	}
+}
+
+// reasonFor maps a Board.Exec error to the short machine-readable
+// string sent over the wire in an invalidMove message.
+func reasonFor(err error) string {
+	var moveErr *MoveError
+	if !errors.As(err, &moveErr) {
+		return "unknown"
+	}
+	switch {
+	case errors.Is(moveErr.Reason, ErrOccupied):
+		return "occupied"
+	case errors.Is(moveErr.Reason, ErrNoCapture):
+		return "noCapture"
+	case errors.Is(moveErr.Reason, ErrMustPass):
+		return "mustPass"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,135 @@
+package othello
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestPairSessionVsAI covers the vsAI branch of pairSession: the
+// session always gets its own game, playing Black against the AI as
+// White, and never touches the shared lobby.
+func TestPairSessionVsAI(t *testing.T) {
+	sess := &wsSession{}
+	game := pairSession(sess, true)
+
+	if sess.color != Black {
+		t.Fatalf("color = %v, want Black", sess.color)
+	}
+	if !game.useAI || game.aiColor != White {
+		t.Fatalf("game = %+v, want useAI=true aiColor=White", game)
+	}
+	if game.players[0] != sess {
+		t.Fatal("sess was not seated as players[0]")
+	}
+	if waiting != nil {
+		t.Fatal("vsAI pairing must not touch the lobby")
+	}
+}
+
+// TestPairSessionLobby covers the two-human path: the first session to
+// arrive waits in the lobby as Black, and the second is paired into
+// the same game as White, clearing the lobby behind it.
+func TestPairSessionLobby(t *testing.T) {
+	first := &wsSession{}
+	game1 := pairSession(first, false)
+	if first.color != Black {
+		t.Fatalf("first.color = %v, want Black", first.color)
+	}
+	if waiting != first {
+		t.Fatal("first session should be left waiting in the lobby")
+	}
+
+	second := &wsSession{}
+	game2 := pairSession(second, false)
+	if second.color != White {
+		t.Fatalf("second.color = %v, want White", second.color)
+	}
+	if game2 != game1 {
+		t.Fatal("second session should be paired into the first session's game")
+	}
+	if waiting != nil {
+		t.Fatal("lobby should be cleared once a pair is formed")
+	}
+
+	leaveGame(first)
+	leaveGame(second)
+}
+
+func standardWSBoard() Board {
+	var b Board
+	b.Pieces[3][3] = White
+	b.Pieces[3][4] = Black
+	b.Pieces[4][3] = Black
+	b.Pieces[4][4] = White
+	b.Next = Black
+	b.Sync()
+	return b
+}
+
+// TestSettleTurnNoOpWhenMovesExist covers settleTurn's fast path: a
+// side with a legal move is left untouched.
+func TestSettleTurnNoOpWhenMovesExist(t *testing.T) {
+	b := standardWSBoard()
+	if over := settleTurn(&b); over {
+		t.Fatal("settleTurn reported game over from the starting position")
+	}
+	if b.Next != Black {
+		t.Fatalf("Next = %v, want Black (no pass should have happened)", b.Next)
+	}
+}
+
+// TestSettleTurnGameOver covers settleTurn's terminal case: a board
+// with no legal move for either side passes once and reports over,
+// the condition broadcastGameOver relies on.
+func TestSettleTurnGameOver(t *testing.T) {
+	var b Board
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			b.Pieces[y][x] = Black
+		}
+	}
+	b.Next = White
+	b.Sync()
+
+	if over := settleTurn(&b); !over {
+		t.Fatal("settleTurn did not report game over on a full board")
+	}
+}
+
+func TestWinner(t *testing.T) {
+	b := standardWSBoard()
+	if w := winner(b); w != "draw" {
+		t.Fatalf("winner of the starting position = %q, want draw", w)
+	}
+
+	b.Pieces[0][0] = Black
+	if w := winner(b); w != "black" {
+		t.Fatalf("winner = %q, want black", w)
+	}
+}
+
+func TestPieceLabel(t *testing.T) {
+	cases := map[Piece]string{Black: "black", White: "white", Empty: ""}
+	for p, want := range cases {
+		if got := pieceLabel(p); got != want {
+			t.Fatalf("pieceLabel(%v) = %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestReasonFor(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{&MoveError{Reason: ErrOccupied}, "occupied"},
+		{&MoveError{Reason: ErrNoCapture}, "noCapture"},
+		{&MoveError{Reason: ErrMustPass}, "mustPass"},
+		{errors.New("boom"), "unknown"},
+	}
+	for _, c := range cases {
+		if got := reasonFor(c.err); got != c.want {
+			t.Fatalf("reasonFor(%v) = %q, want %q", c.err, got, c.want)
+		}
+	}
+}